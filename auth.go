@@ -0,0 +1,68 @@
+package goemail
+
+import (
+	"net/smtp"
+	"strings"
+
+	"github.com/dajohi/goemail/smtpauth"
+)
+
+// smtpAuthConfig bundles the authentication info dialSMTP needs once it
+// knows which mechanisms the server advertises in EHLO.
+type smtpAuthConfig struct {
+	// auth, when non-nil, is used as-is and overrides username, password,
+	// and authMethod entirely.
+	auth smtp.Auth
+
+	username   string
+	password   string
+	authMethod string // "", "login", "cram-md5", or "xoauth2"
+}
+
+// authPriority lists the mechanisms resolveAuth will pick among when
+// authMethod is unspecified, strongest first.
+var authPriority = []string{"CRAM-MD5", "LOGIN", "PLAIN"}
+
+// resolveAuth returns the smtp.Auth to use for the connection described
+// by cfg, or nil if no credentials were configured. cfg.auth, if set,
+// always wins. Otherwise, an explicit cfg.authMethod is honored as-is;
+// with no explicit method, resolveAuth negotiates by picking the
+// strongest mechanism client advertises via its AUTH extension,
+// falling back to PLAIN if the server didn't advertise one.
+func resolveAuth(client *smtp.Client, cfg smtpAuthConfig, server string) smtp.Auth {
+	if cfg.auth != nil {
+		return cfg.auth
+	}
+	if cfg.username == "" {
+		return nil
+	}
+	if cfg.authMethod != "" {
+		return authForMethod(cfg.authMethod, cfg.username, cfg.password, server)
+	}
+
+	_, params := client.Extension("AUTH")
+	advertised := strings.Fields(params)
+	for _, mechanism := range authPriority {
+		for _, a := range advertised {
+			if strings.EqualFold(a, mechanism) {
+				return authForMethod(strings.ToLower(mechanism), cfg.username, cfg.password, server)
+			}
+		}
+	}
+
+	return smtp.PlainAuth("", cfg.username, cfg.password, server)
+}
+
+// authForMethod returns the smtp.Auth implementing the named mechanism.
+func authForMethod(method, username, password, server string) smtp.Auth {
+	switch method {
+	case "login":
+		return smtpauth.LoginAuth(username, password, server)
+	case "cram-md5":
+		return smtp.CRAMMD5Auth(username, password)
+	case "xoauth2":
+		return smtpauth.XOAuth2Auth(username, password)
+	default:
+		return smtp.PlainAuth("", username, password, server)
+	}
+}
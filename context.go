@@ -0,0 +1,159 @@
+package goemail
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+)
+
+// dialSMTPContext connects to server, performs HELO/EHLO, negotiates
+// STARTTLS when available and not already using implicit TLS, and
+// authenticates if authCfg carries credentials, honoring ctx throughout.
+// The connection's deadline is refreshed from ctx before each exchange,
+// and a goroutine closes it if ctx is done before dialSMTPContext
+// returns, so a stuck server cannot hang the caller. The returned net.Conn
+// lets SendContext continue to honor ctx for the remainder of the
+// session.
+func dialSMTPContext(ctx context.Context, scheme, server, hostname string, tlsCfg smtpTLSConfig, authCfg smtpAuthConfig) (*smtp.Client, net.Conn, error) {
+	var conn net.Conn
+	var err error
+
+	if scheme == "smtps" {
+		d := &tls.Dialer{Config: tlsCfg.tlsConfig()}
+		if conn, err = d.DialContext(ctx, "tcp", server); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		d := &net.Dialer{}
+		if conn, err = d.DialContext(ctx, "tcp", server); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	if err = refreshDeadline(conn, ctx); err != nil {
+		return nil, nil, err
+	}
+
+	client, err := smtp.NewClient(conn, server)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Send HELO/EHLO
+	if err = client.Hello(hostname); err != nil {
+		return nil, nil, err
+	}
+
+	// Check if STARTTLS is supported if not smtps.
+	if scheme != "smtps" {
+		switch hasStartTLS, _ := client.Extension("STARTTLS"); {
+		case hasStartTLS:
+			if err = refreshDeadline(conn, ctx); err != nil {
+				return nil, nil, err
+			}
+			if err = client.StartTLS(tlsCfg.tlsConfig()); err != nil {
+				return nil, nil, err
+			}
+		case tlsCfg.startTLSRequired:
+			return nil, nil, ErrStartTLSRequired
+		}
+	}
+
+	// Send authentication, if specified
+	if err = refreshDeadline(conn, ctx); err != nil {
+		return nil, nil, err
+	}
+	if auth := resolveAuth(client, authCfg, server); auth != nil {
+		if err = client.Auth(auth); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return client, conn, nil
+}
+
+// refreshDeadline sets conn's deadline from ctx, if ctx carries one.
+func refreshDeadline(conn net.Conn, ctx context.Context) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	return conn.SetDeadline(deadline)
+}
+
+// SendContext connects to the server and sends msg, honoring ctx's
+// deadline and cancellation across the dial, TLS handshake, EHLO, AUTH,
+// and MAIL/RCPT/DATA/QUIT exchange. A goroutine closes the connection if
+// ctx is done before SendContext returns, so a stuck server cannot hang
+// the caller indefinitely.
+func (s *SMTP) SendContext(ctx context.Context, msg *Message) error {
+	if len(msg.Recipients()) < 1 {
+		return ErrNoRecipients
+	}
+
+	client, conn, err := dialSMTPContext(ctx, s.scheme, s.server, s.hostname, s.tlsConfig(), s.authConfig())
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	if err = refreshDeadline(conn, ctx); err != nil {
+		return err
+	}
+
+	// MAIL FROM
+	if err = client.Mail(msg.From()); err != nil {
+		return err
+	}
+
+	// RCPT TO
+	for _, rcpt := range msg.Recipients() {
+		if err = refreshDeadline(conn, ctx); err != nil {
+			return err
+		}
+		if err = client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	// DATA
+	if err = refreshDeadline(conn, ctx); err != nil {
+		return err
+	}
+	dataBuf, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = dataBuf.Write(msg.Body()); err != nil {
+		return err
+	}
+
+	_ = dataBuf.Close()
+
+	if err = refreshDeadline(conn, ctx); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,49 @@
+package goemail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSMTP_SendContext_Timeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// Accept connections but never reply, so the client blocks on the
+	// server's banner until ctx's deadline forces it closed.
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	s, err := NewSMTP(fmt.Sprintf("smtp://%s", ln.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := NewMessage("from@example.com", "subject", "body")
+	msg.AddTo("to@example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = s.SendContext(ctx, msg)
+	if err == nil {
+		t.Fatal("expected an error from a server that never responds")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("SendContext took %s to return after its deadline expired", elapsed)
+	}
+}
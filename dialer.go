@@ -0,0 +1,151 @@
+package goemail
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/smtp"
+	"net/textproto"
+)
+
+// SendCloser is a Sender backed by a live connection, returned by
+// Dialer.Dial. It is an alias for Sender, kept as a distinct name for
+// parity with the Dialer/SendCloser split familiar from other mail
+// packages.
+type SendCloser = Sender
+
+// Dialer holds the parameters needed to open a connection to an SMTP
+// server, so that connection can be reused across many calls to Send
+// instead of being torn down and re-established for every message.
+type Dialer struct {
+	scheme   string
+	server   string
+	hostname string
+	tls      smtpTLSConfig
+	auth     smtpAuthConfig
+}
+
+// NewDialer is called with smtp[s]://[username:[password]]@server:[port],
+// the same URL format accepted by NewSMTP.
+func NewDialer(rawURL string) (*Dialer, error) {
+	s, err := NewSMTP(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dialer{
+		scheme:   s.scheme,
+		server:   s.server,
+		hostname: s.hostname,
+		tls:      s.tlsConfig(),
+		auth:     s.authConfig(),
+	}
+	return d, nil
+}
+
+// Dial connects to the server described by d and returns a SendCloser
+// backed by that connection. The caller must call Close on the result
+// when done sending.
+func (d *Dialer) Dial() (SendCloser, error) {
+	client, err := dialSMTP(d.scheme, d.server, d.hostname, d.tls, d.auth)
+	if err != nil {
+		return nil, err
+	}
+	return &smtpConn{client: client}, nil
+}
+
+// smtpConn is a live SMTP connection returned by Dialer.Dial. It
+// implements SendCloser, issuing RSET before every message after the
+// first so a single connection can deliver a batch without reconnecting.
+type smtpConn struct {
+	client *smtp.Client
+	sent   bool
+}
+
+// Send delivers msg over the existing connection.
+func (c *smtpConn) Send(msg *Message) error {
+	if len(msg.Recipients()) < 1 {
+		return ErrNoRecipients
+	}
+
+	if c.sent {
+		if err := c.client.Reset(); err != nil {
+			return err
+		}
+	}
+	c.sent = true
+
+	if err := c.client.Mail(msg.From()); err != nil {
+		return err
+	}
+	for _, rcpt := range msg.Recipients() {
+		if err := c.client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg.Body()); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Close sends QUIT and releases the underlying connection.
+func (c *smtpConn) Close() error {
+	return c.client.Quit()
+}
+
+// Send opens a connection via d, sends each of msgs in order, and closes
+// the connection when done. If a message fails with a transient error,
+// Send reconnects once and retries that message before giving up.
+func Send(d *Dialer, msgs ...*Message) error {
+	sc, err := d.Dial()
+	if err != nil {
+		return err
+	}
+	// sc is reassigned on reconnect below, so close through the pointer
+	// rather than a plain defer, which would capture the original
+	// connection and leak whatever sc is reassigned to.
+	defer func() {
+		if sc != nil {
+			sc.Close()
+		}
+	}()
+
+	for _, msg := range msgs {
+		if err = sc.Send(msg); err != nil {
+			if !isTransientErr(err) {
+				return err
+			}
+
+			_ = sc.Close()
+			if sc, err = d.Dial(); err != nil {
+				return err
+			}
+			if err = sc.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// isTransientErr reports whether err represents a transient failure (a
+// 4xx SMTP reply, or the connection being dropped) that is worth retrying
+// on a fresh connection, as opposed to a permanent 5xx rejection.
+func isTransientErr(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)
+}
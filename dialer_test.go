@@ -0,0 +1,130 @@
+package goemail
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSMTPServer speaks just enough SMTP to exercise Dialer/Send's
+// reconnect-on-transient-error path: it rejects RCPT TO on the first
+// connection with a 450 and accepts it on every later connection.
+type fakeSMTPServer struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	conns int
+	quit  map[int]bool
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{ln: ln, quit: make(map[int]bool)}
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTPServer) addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns++
+		idx := s.conns
+		s.mu.Unlock()
+
+		go s.handle(conn, idx)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn, idx int) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "220 localhost ESMTP\r\n")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimRight(line, "\r\n"))
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			fmt.Fprint(conn, "250 localhost\r\n")
+		case strings.HasPrefix(cmd, "MAIL FROM"):
+			fmt.Fprint(conn, "250 OK\r\n")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			if idx == 1 {
+				fmt.Fprint(conn, "450 please try again later\r\n")
+			} else {
+				fmt.Fprint(conn, "250 OK\r\n")
+			}
+		case cmd == "DATA":
+			fmt.Fprint(conn, "354 go ahead\r\n")
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.TrimRight(dataLine, "\r\n") == "." {
+					break
+				}
+			}
+			fmt.Fprint(conn, "250 OK\r\n")
+		case cmd == "RSET":
+			fmt.Fprint(conn, "250 OK\r\n")
+		case cmd == "QUIT":
+			s.mu.Lock()
+			s.quit[idx] = true
+			s.mu.Unlock()
+			fmt.Fprint(conn, "221 bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "500 unrecognized command\r\n")
+		}
+	}
+}
+
+func TestSend_ReconnectOnTransientError(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	defer srv.ln.Close()
+
+	d, err := NewDialer(fmt.Sprintf("smtp://%s", srv.addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := NewMessage("from@example.com", "subject", "body")
+	msg.AddTo("to@example.com")
+
+	if err := Send(d, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.conns != 2 {
+		t.Fatalf("got %d connections, want 2 (one rejected, one retried)", srv.conns)
+	}
+	if !srv.quit[1] {
+		t.Fatal("expected the rejected connection to be closed with QUIT before reconnecting")
+	}
+	if !srv.quit[2] {
+		t.Fatal("expected the retried connection to be closed with QUIT when Send returns")
+	}
+}
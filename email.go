@@ -2,8 +2,8 @@ package goemail
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -17,8 +17,11 @@ import (
 
 // Define errors
 var (
-	ErrInvalidScheme = errors.New("invalid scheme")
-	ErrNoRecipients  = errors.New("no recipients specified")
+	ErrInvalidScheme     = errors.New("invalid scheme")
+	ErrNoRecipients      = errors.New("no recipients specified")
+	ErrInvalidAuthMethod = errors.New("invalid auth method")
+	ErrInvalidTLSMode    = errors.New("invalid tls mode")
+	ErrStartTLSRequired  = errors.New("server does not support STARTTLS")
 )
 
 // Message defines an email message, headers, and attachments.
@@ -33,14 +36,117 @@ type Message struct {
 	body            string
 	bodyContentType string
 	attachments     map[string][]byte
+	alternatives    []alternative
+	embeds          []embed
+}
+
+// alternative is an additional representation of the message body, added
+// with AddAlternative, for example an HTML version alongside the
+// original plain-text body.
+type alternative struct {
+	contentType string
+	body        string
+}
+
+// embed is an inline attachment added with Embed, referenced from an
+// HTML body via a "cid:" URL.
+type embed struct {
+	cid      string
+	filename string
+	data     []byte
 }
 
 // SMTP defines and smtp server along with the auth info.
 type SMTP struct {
 	scheme   string
 	server   string
-	auth     *smtp.Auth
 	hostname string
+	host     string // server hostname, without port, for TLS verification
+
+	// username and password come from the URL's userinfo. authMethod is
+	// the explicit mechanism requested via the "auth" query parameter
+	// ("login", "cram-md5", or "xoauth2"); when empty, the strongest
+	// mechanism the server advertises in EHLO is used instead.
+	username   string
+	password   string
+	authMethod string
+
+	// auth, when set via SetAuth, overrides username/password/authMethod
+	// entirely, for callers that need to construct an smtp.Auth
+	// programmatically.
+	auth smtp.Auth
+
+	// tlsVerify and startTLSRequired come from the "tls" query parameter
+	// ("verify", "skip", "starttls-required", or "starttls-optional").
+	// tlsVerify is ignored once TLSConfig is set; startTLSRequired still
+	// applies.
+	tlsVerify        bool
+	startTLSRequired bool
+
+	// TLSConfig, when set, is used as-is for both implicit TLS (smtps)
+	// and STARTTLS, overriding tlsVerify entirely. This lets callers
+	// supply their own root pool or pin a certificate.
+	TLSConfig *tls.Config
+}
+
+// SetAuth overrides any authentication derived from the connection URL
+// with auth, for callers that need to construct an smtp.Auth
+// programmatically.
+func (s *SMTP) SetAuth(auth smtp.Auth) {
+	s.auth = auth
+}
+
+// tlsConfig bundles the TLS info dialSMTP needs to build a *tls.Config.
+func (s *SMTP) tlsConfig() smtpTLSConfig {
+	return smtpTLSConfig{
+		config:           s.TLSConfig,
+		verify:           s.tlsVerify,
+		serverName:       s.host,
+		startTLSRequired: s.startTLSRequired,
+	}
+}
+
+// authConfig bundles the authentication info dialSMTP needs to
+// authenticate once it knows the mechanisms the server advertises.
+func (s *SMTP) authConfig() smtpAuthConfig {
+	return smtpAuthConfig{
+		auth:       s.auth,
+		username:   s.username,
+		password:   s.password,
+		authMethod: s.authMethod,
+	}
+}
+
+// Sender defines a mail transport capable of delivering a Message.
+// Implementations include SMTP, for talking directly to a mail server,
+// and Mailgun, for delivering through a hosted mail API.
+type Sender interface {
+	// Send delivers msg.
+	Send(msg *Message) error
+
+	// Close releases any resources held by the Sender.
+	Close() error
+}
+
+// NewSender parses rawURL and returns the Sender appropriate for its
+// scheme: smtp:// and smtps:// return an *SMTP that speaks directly to a
+// mail server, while mailgun+https:// returns a *Mailgun that delivers
+// through the hosted Mailgun HTTP API. This lets callers written against
+// Sender switch transports by changing a URL.
+func NewSender(rawURL string) (Sender, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "smtp", "smtps":
+		return NewSMTP(rawURL)
+	case "mailgun+https":
+		return NewMailgun(rawURL)
+	default:
+		return nil, ErrInvalidScheme
+	}
 }
 
 func newMessage(from, subject, body, contenttype string) *Message {
@@ -81,6 +187,20 @@ func (m *Message) AddAttachmentFromFile(filename string) error {
 	return nil
 }
 
+// AddAlternative attaches an additional representation of the message
+// body in the given contentType, for example an HTML version alongside
+// the original plain-text body. Alternatives are ordered from least to
+// most preferred, matching the order they are added in.
+func (m *Message) AddAlternative(contentType, body string) {
+	m.alternatives = append(m.alternatives, alternative{contentType, body})
+}
+
+// Embed attaches data as an inline part identified by cid, for reference
+// from an HTML body via <img src="cid:cid">.
+func (m *Message) Embed(cid, filename string, data []byte) {
+	m.embeds = append(m.embeds, embed{cid, filename, data})
+}
+
 // AddCC adds a single email address to the CC list.
 func (m *Message) AddCC(emailAddr string) {
 	m.cc = append(m.cc, emailAddr)
@@ -96,44 +216,37 @@ func (m *Message) AddTo(emailAddr string) {
 	m.to = append(m.to, emailAddr)
 }
 
-// Body returns the formatted message body.
+// Body returns the formatted message as a complete RFC 5322 document
+// with CRLF line endings. Non-ASCII subjects, display names, and
+// attachment filenames are emitted as RFC 2047 encoded-words; text
+// bodies are quoted-printable encoded and attachments are base64
+// encoded and wrapped at 76 columns, per RFC 2045.
+//
+// The part structure nests only as deeply as the message needs:
+// multipart/mixed wraps attachments, multipart/related wraps embedded
+// images, and multipart/alternative wraps additional body
+// representations, collapsing to a single part when none of those are
+// present.
 func (m *Message) Body() []byte {
-	buf := bytes.NewBuffer(nil)
-	from := fmt.Sprintf("\"%s\" <%s>", m.name, m.from)
-	buf.WriteString("From: " + from + "\n")
-	buf.WriteString("Date: " + m.date + "\n")
-	buf.WriteString("To: " + strings.Join(m.to, ",") + "\n")
-	if len(m.cc) > 0 {
-		buf.WriteString("Cc: " + strings.Join(m.cc, ",") + "\n")
-	}
-	buf.WriteString("Subject: " + m.subject + "\n")
-	buf.WriteString("MIME-Version: 1.0\n")
-
-	boundary := "mnwKuycHoXCwn9S5UY6avz8ZGJPEeUdMPS"
+	buf := &bytes.Buffer{}
 
-	if len(m.attachments) > 0 {
-		buf.WriteString("Content-Type: multipart/mixed; boundary=" + boundary + "\n")
-		buf.WriteString("--" + boundary + "\n")
+	writeHeader(buf, "From", fmt.Sprintf("%s <%s>", encodeDisplayName(m.name), m.from))
+	writeHeader(buf, "Date", m.date)
+	writeHeader(buf, "To", strings.Join(m.to, ","))
+	if len(m.cc) > 0 {
+		writeHeader(buf, "Cc", strings.Join(m.cc, ","))
 	}
+	writeHeader(buf, "Subject", encodeWord(m.subject))
+	writeHeader(buf, "MIME-Version", "1.0")
 
-	buf.WriteString(fmt.Sprintf("Content-Type: %s; charset=utf-8\n", m.bodyContentType))
-	buf.WriteString(m.body)
-
-	if len(m.attachments) > 0 {
-		for k, v := range m.attachments {
-			buf.WriteString("\n\n--" + boundary + "\n")
-			buf.WriteString("Content-Type: application/octet-stream\n")
-			buf.WriteString("Content-Transfer-Encoding: base64\n")
-			buf.WriteString("Content-Disposition: attachment; filename=\"" + k + "\"\n\n")
-
-			b64 := make([]byte, base64.StdEncoding.EncodedLen(len(v)))
-			base64.StdEncoding.Encode(b64, v)
-			buf.Write(b64)
-			buf.WriteString("\n--" + boundary)
+	top := m.topLevelPart()
+	for _, k := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if v := top.header.Get(k); v != "" {
+			writeHeader(buf, k, v)
 		}
-
-		buf.WriteString("--")
 	}
+	buf.WriteString("\r\n")
+	buf.Write(top.body)
 
 	return buf.Bytes()
 }
@@ -180,8 +293,10 @@ func NewSMTP(rawURL string) (*SMTP, error) {
 	}
 
 	mysmtp := &SMTP{
-		scheme:   url.Scheme,
-		hostname: hostname,
+		scheme:    url.Scheme,
+		hostname:  hostname,
+		host:      url.Hostname(),
+		tlsVerify: true,
 	}
 
 	_, _, err = net.SplitHostPort(url.Host)
@@ -193,94 +308,50 @@ func NewSMTP(rawURL string) (*SMTP, error) {
 
 	if url.User != nil {
 		p, _ := url.User.Password()
-
-		// - put host:port in the fourth argument here as there is a "wrong host name"
-		//   check in go SMTP library auth.go, May have better solution but need
-		//   to understand the purpose of the check
-		a := smtp.PlainAuth("", url.User.Username(), p, mysmtp.server)
-
-		mysmtp.auth = &a
-	}
-	return mysmtp, nil
-}
-
-// Send connects to the server and sends the email message.
-func (s *SMTP) Send(msg *Message) error {
-	var conn net.Conn
-	var err error
-
-	recipients := msg.Recipients()
-	if len(recipients) < 1 {
-		return ErrNoRecipients
-	}
-
-	if s.scheme == "smtps" {
-		tlscfg := tls.Config{
-			InsecureSkipVerify: true,
-		}
-		if conn, err = tls.Dial("tcp", s.server, &tlscfg); err != nil {
-			return err
-		}
-	} else {
-		if conn, err = net.Dial("tcp", s.server); err != nil {
-			return err
-		}
-	}
-
-	client, err := smtp.NewClient(conn, s.server)
-	if err != nil {
-		return err
-	}
-
-	// Send HELO/EHLO
-	if err = client.Hello(s.hostname); err != nil {
-		return err
-	}
-
-	// Check if STARTTLS is supported if not smtps.
-	if s.scheme != "smtps" {
-		hasStartTLS, _ := client.Extension("STARTTLS")
-		if hasStartTLS {
-			tlscfg := tls.Config{
-				InsecureSkipVerify: true,
-			}
-			if err = client.StartTLS(&tlscfg); err != nil {
-				return err
-			}
+		mysmtp.username = url.User.Username()
+		mysmtp.password = p
+
+		switch authMethod := url.Query().Get("auth"); authMethod {
+		case "", "login", "cram-md5", "xoauth2":
+			mysmtp.authMethod = authMethod
+		default:
+			return nil, ErrInvalidAuthMethod
 		}
 	}
 
-	// Send authentication, if specified
-	if s.auth != nil {
-		if err = client.Auth(*s.auth); err != nil {
-			return err
-		}
+	switch tlsMode := url.Query().Get("tls"); tlsMode {
+	case "", "verify":
+	case "skip":
+		mysmtp.tlsVerify = false
+	case "starttls-required":
+		mysmtp.startTLSRequired = true
+	case "starttls-optional":
+	default:
+		return nil, ErrInvalidTLSMode
 	}
 
-	// MAIL FROM
-	if err = client.Mail(msg.From()); err != nil {
-		return err
-	}
-
-	// RCPT TO
-	for _, rcpt := range msg.Recipients() {
-		if err = client.Rcpt(rcpt); err != nil {
-			return err
-		}
-	}
-
-	// DATA
-	dataBuf, err := client.Data()
-	if err != nil {
-		return err
-	}
+	return mysmtp, nil
+}
 
-	_, err = dataBuf.Write(msg.Body())
-	if err != nil {
-		return err
-	}
+// dialSMTP connects to server, performs HELO/EHLO, negotiates STARTTLS
+// when available and not already using implicit TLS, and authenticates
+// if authCfg carries credentials. It is used by Dialer.Dial; SMTP.Send
+// goes through SendContext, which calls dialSMTPContext directly, so both
+// single-shot and persistent connections negotiate the session the same
+// way. It is a thin wrapper around dialSMTPContext with a background
+// context, for callers that don't need cancellation.
+func dialSMTP(scheme, server, hostname string, tlsCfg smtpTLSConfig, authCfg smtpAuthConfig) (*smtp.Client, error) {
+	client, _, err := dialSMTPContext(context.Background(), scheme, server, hostname, tlsCfg, authCfg)
+	return client, err
+}
 
-	_ = dataBuf.Close()
+// Send connects to the server and sends the email message.
+func (s *SMTP) Send(msg *Message) error {
+	return s.SendContext(context.Background(), msg)
+}
 
-	return client.Quit()
+// Close satisfies the Sender interface. SMTP does not hold a connection
+// open between calls to Send, so there is nothing to release.
+func (s *SMTP) Close() error {
+	return nil
 }
@@ -0,0 +1,123 @@
+package goemail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultMailgunAPI is the Mailgun API host used when none is present in
+// the mailgun+https:// URL.
+const defaultMailgunAPI = "api.mailgun.net"
+
+// ErrMailgunMissingKey is returned when a mailgun+https:// URL has no API
+// key in its userinfo.
+var ErrMailgunMissingKey = errors.New("mailgun: missing API key")
+
+// ErrMailgunMissingDomain is returned when a mailgun+https:// URL has no
+// sending domain in its path.
+var ErrMailgunMissingDomain = errors.New("mailgun: missing domain")
+
+// Mailgun is a Sender that delivers messages through the hosted Mailgun
+// HTTP API instead of SMTP, for environments that block outbound port
+// 25/587.
+type Mailgun struct {
+	apiBase string
+	domain  string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewMailgun is called with mailgun+https://key@host/domain, where host
+// defaults to api.mailgun.net and domain is the sending domain configured
+// in the Mailgun control panel.
+func NewMailgun(rawURL string) (*Mailgun, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "mailgun+https" {
+		return nil, ErrInvalidScheme
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, ErrMailgunMissingKey
+	}
+
+	domain := strings.Trim(u.Path, "/")
+	if domain == "" {
+		return nil, ErrMailgunMissingDomain
+	}
+
+	host := u.Host
+	if host == "" {
+		host = defaultMailgunAPI
+	}
+
+	mg := &Mailgun{
+		apiBase: host,
+		domain:  domain,
+		apiKey:  u.User.Username(),
+		client:  &http.Client{},
+	}
+	return mg, nil
+}
+
+// Send delivers msg by POSTing its raw MIME representation to Mailgun's
+// messages.mime endpoint, along with an explicit "to" field per
+// recipient. The explicit fields are what actually control delivery;
+// Message.Body never writes a Bcc header, so without them a BCC
+// recipient would be silently dropped.
+func (mg *Mailgun) Send(msg *Message) error {
+	if len(msg.Recipients()) < 1 {
+		return ErrNoRecipients
+	}
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+
+	for _, rcpt := range msg.Recipients() {
+		if err := w.WriteField("to", rcpt); err != nil {
+			return err
+		}
+	}
+
+	part, err := w.CreateFormFile("message", "message.mime")
+	if err != nil {
+		return err
+	}
+	if _, err = part.Write(msg.Body()); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	apiURL := fmt.Sprintf("https://%s/v3/%s/messages.mime", mg.apiBase, mg.domain)
+	req, err := http.NewRequest(http.MethodPost, apiURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.SetBasicAuth("api", mg.apiKey)
+
+	resp, err := mg.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("mailgun: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Close releases idle HTTP connections held by the Mailgun client.
+func (mg *Mailgun) Close() error {
+	mg.client.CloseIdleConnections()
+	return nil
+}
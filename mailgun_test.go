@@ -0,0 +1,186 @@
+package goemail
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewMailgun(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		wantErr error
+		apiBase string
+		domain  string
+		apiKey  string
+	}{
+		{
+			name:    "missing key",
+			rawURL:  "mailgun+https://example.com/mydomain.com",
+			wantErr: ErrMailgunMissingKey,
+		},
+		{
+			name:    "missing domain",
+			rawURL:  "mailgun+https://key@example.com",
+			wantErr: ErrMailgunMissingDomain,
+		},
+		{
+			name:    "wrong scheme",
+			rawURL:  "smtp://key@example.com/mydomain.com",
+			wantErr: ErrInvalidScheme,
+		},
+		{
+			name:    "default API host",
+			rawURL:  "mailgun+https://key@/mydomain.com",
+			apiBase: defaultMailgunAPI,
+			domain:  "mydomain.com",
+			apiKey:  "key",
+		},
+		{
+			name:    "custom host",
+			rawURL:  "mailgun+https://key@api.eu.mailgun.net/mydomain.com",
+			apiBase: "api.eu.mailgun.net",
+			domain:  "mydomain.com",
+			apiKey:  "key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mg, err := NewMailgun(tt.rawURL)
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mg.apiBase != tt.apiBase {
+				t.Errorf("apiBase = %q, want %q", mg.apiBase, tt.apiBase)
+			}
+			if mg.domain != tt.domain {
+				t.Errorf("domain = %q, want %q", mg.domain, tt.domain)
+			}
+			if mg.apiKey != tt.apiKey {
+				t.Errorf("apiKey = %q, want %q", mg.apiKey, tt.apiKey)
+			}
+		})
+	}
+}
+
+func TestMailgun_Send(t *testing.T) {
+	msg := NewMessage("from@example.com", "subject", "body")
+	msg.AddTo("to@example.com")
+
+	t.Run("success", func(t *testing.T) {
+		var gotPath, gotUser, gotPass string
+		var gotOK bool
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			gotUser, gotPass, gotOK = r.BasicAuth()
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Fatal(err)
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+
+		// Send always builds an https:// URL; redirect it at the plain
+		// http:// httptest.Server via a RoundTripper instead.
+		mg := &Mailgun{
+			apiBase: srv.URL[len("http://"):],
+			domain:  "mydomain.com",
+			apiKey:  "key",
+			client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				req.URL.Scheme = "http"
+				return http.DefaultTransport.RoundTrip(req)
+			})},
+		}
+
+		if err := mg.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+
+		wantPath := fmt.Sprintf("/v3/%s/messages.mime", mg.domain)
+		if gotPath != wantPath {
+			t.Errorf("path = %q, want %q", gotPath, wantPath)
+		}
+		if !gotOK || gotUser != "api" || gotPass != mg.apiKey {
+			t.Errorf("basic auth = (%q, %q, %v), want (api, %s, true)", gotUser, gotPass, gotOK, mg.apiKey)
+		}
+	})
+
+	t.Run("bcc reaches the request", func(t *testing.T) {
+		bccMsg := NewMessage("from@example.com", "subject", "body")
+		bccMsg.AddTo("to@example.com")
+		bccMsg.AddBCC("secret@example.com")
+
+		var gotTo []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Fatal(err)
+			}
+			gotTo = r.MultipartForm.Value["to"]
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+
+		mg := &Mailgun{
+			apiBase: srv.URL[len("http://"):],
+			domain:  "mydomain.com",
+			apiKey:  "key",
+			client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				req.URL.Scheme = "http"
+				return http.DefaultTransport.RoundTrip(req)
+			})},
+		}
+
+		if err := mg.Send(bccMsg); err != nil {
+			t.Fatal(err)
+		}
+
+		found := false
+		for _, to := range gotTo {
+			if to == "secret@example.com" {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("got to fields %v, want secret@example.com among them", gotTo)
+		}
+	})
+
+	t.Run("non-200 status", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer srv.Close()
+
+		mg := &Mailgun{
+			apiBase: srv.URL[len("http://"):],
+			domain:  "mydomain.com",
+			apiKey:  "key",
+			client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				req.URL.Scheme = "http"
+				return http.DefaultTransport.RoundTrip(req)
+			})},
+		}
+
+		if err := mg.Send(msg); err == nil {
+			t.Fatal("expected an error from a non-200 response")
+		}
+	})
+}
+
+// roundTripFunc adapts a function to an http.RoundTripper, used above to
+// redirect Mailgun's hardcoded https:// requests at a plain http://
+// httptest.Server.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,135 @@
+package goemail
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+)
+
+// part is a single node in a message's MIME structure: header holds the
+// part's own headers (Content-Type and friends) and body holds its
+// already-encoded content, which may itself be a nested multipart
+// document.
+type part struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// wrapMultipart combines parts into a single multipart/subtype part with
+// a fresh random boundary.
+func wrapMultipart(subtype string, parts []part) part {
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+
+	for _, p := range parts {
+		pw, err := w.CreatePart(p.header)
+		if err != nil {
+			continue
+		}
+		_, _ = pw.Write(p.body)
+	}
+	_ = w.Close()
+
+	return part{
+		header: textproto.MIMEHeader{
+			"Content-Type": {fmt.Sprintf("multipart/%s; boundary=%s", subtype, w.Boundary())},
+		},
+		body: buf.Bytes(),
+	}
+}
+
+// bodyPart returns the message's primary body as a single
+// quoted-printable encoded part.
+func (m *Message) bodyPart() part {
+	buf := &bytes.Buffer{}
+	writeQuotedPrintable(buf, m.body)
+	return part{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {m.bodyContentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		body: buf.Bytes(),
+	}
+}
+
+// alternativePart wraps the primary body together with any alternatives
+// added via AddAlternative in a multipart/alternative part, or returns
+// the body alone when there are no alternatives.
+func (m *Message) alternativePart() part {
+	base := m.bodyPart()
+	if len(m.alternatives) == 0 {
+		return base
+	}
+
+	parts := make([]part, 0, len(m.alternatives)+1)
+	parts = append(parts, base)
+	for _, alt := range m.alternatives {
+		buf := &bytes.Buffer{}
+		writeQuotedPrintable(buf, alt.body)
+		parts = append(parts, part{
+			header: textproto.MIMEHeader{
+				"Content-Type":              {alt.contentType + "; charset=utf-8"},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			},
+			body: buf.Bytes(),
+		})
+	}
+
+	return wrapMultipart("alternative", parts)
+}
+
+// relatedPart wraps the alternative part together with any images added
+// via Embed in a multipart/related part, or returns the alternative part
+// alone when there are no embeds.
+func (m *Message) relatedPart() part {
+	base := m.alternativePart()
+	if len(m.embeds) == 0 {
+		return base
+	}
+
+	parts := make([]part, 0, len(m.embeds)+1)
+	parts = append(parts, base)
+	for _, e := range m.embeds {
+		buf := &bytes.Buffer{}
+		writeBase64(buf, e.data)
+		parts = append(parts, part{
+			header: textproto.MIMEHeader{
+				"Content-Type":              {"application/octet-stream"},
+				"Content-Transfer-Encoding": {"base64"},
+				"Content-ID":                {"<" + e.cid + ">"},
+				"Content-Disposition":       {fmt.Sprintf(`inline; filename="%s"`, encodeWord(e.filename))},
+			},
+			body: buf.Bytes(),
+		})
+	}
+
+	return wrapMultipart("related", parts)
+}
+
+// topLevelPart wraps the related part together with any attachments in a
+// multipart/mixed part, or returns the related part alone when there are
+// no attachments. This is the outermost part of Message.Body.
+func (m *Message) topLevelPart() part {
+	base := m.relatedPart()
+	if len(m.attachments) == 0 {
+		return base
+	}
+
+	parts := make([]part, 0, len(m.attachments)+1)
+	parts = append(parts, base)
+	for filename, data := range m.attachments {
+		buf := &bytes.Buffer{}
+		writeBase64(buf, data)
+		parts = append(parts, part{
+			header: textproto.MIMEHeader{
+				"Content-Type":              {"application/octet-stream"},
+				"Content-Transfer-Encoding": {"base64"},
+				"Content-Disposition":       {fmt.Sprintf(`attachment; filename="%s"`, encodeWord(filename))},
+			},
+			body: buf.Bytes(),
+		})
+	}
+
+	return wrapMultipart("mixed", parts)
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2014-2019 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package goemail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestMessage_BodyEncodedHeaders(t *testing.T) {
+	msg := NewMessage("blah@example.com", "résumé café", "body")
+	msg.AddTo("someone@else.com")
+	msg.SetName("Jörg Schmidt")
+
+	body := msg.Body()
+
+	stdMsg, err := mail.ReadMessage(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := new(mime.WordDecoder)
+	subject, err := dec.DecodeHeader(stdMsg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != msg.subject {
+		t.Fatalf("got subject %q, want %q", subject, msg.subject)
+	}
+
+	addr, err := mail.ParseAddress(stdMsg.Header.Get("From"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.Name != msg.name {
+		t.Fatalf("got from name %q, want %q", addr.Name, msg.name)
+	}
+	if addr.Address != msg.from {
+		t.Fatalf("got from address %q, want %q", addr.Address, msg.from)
+	}
+}
+
+func TestMessage_BodyQuotedPrintable(t *testing.T) {
+	msg := NewMessage("blah@example.com", "plain email", "café = 100%\r\n")
+	msg.AddTo("someone@else.com")
+
+	body := msg.Body()
+	if !bytes.Contains(body, []byte("\r\n\r\n")) {
+		t.Fatal("expected a blank line separating headers from the body")
+	}
+
+	stdMsg, err := mail.ReadMessage(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stdMsg.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+		t.Fatalf("got Content-Transfer-Encoding %q, want quoted-printable", got)
+	}
+}
+
+func TestMessage_BodyAttachments(t *testing.T) {
+	msg := NewMessage("blah@example.com", "with attachment", "see attached")
+	msg.AddTo("someone@else.com")
+	msg.AddAttachment("résumé.txt", []byte("hello, world"))
+
+	body := msg.Body()
+
+	stdMsg, err := mail.ReadMessage(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(stdMsg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		t.Fatalf("got media type %q, want multipart/*", mediaType)
+	}
+
+	mr := multipart.NewReader(stdMsg.Body, params["boundary"])
+
+	bodyPart, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := bodyPart.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("got body part Content-Type %q, want text/plain", ct)
+	}
+
+	attachPart, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := ioutil.ReadAll(attachPart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clean := strings.NewReplacer("\r", "", "\n", "").Replace(string(encoded))
+	data, err := base64.StdEncoding.DecodeString(clean)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello, world" {
+		t.Fatalf("got attachment contents %q, want %q", data, "hello, world")
+	}
+
+	if _, err = mr.NextPart(); err == nil {
+		t.Fatal("expected only two parts")
+	}
+}
+
+func TestMessage_BodyAlternativeAndEmbed(t *testing.T) {
+	msg := NewMessage("blah@example.com", "html email", "plain version")
+	msg.AddTo("someone@else.com")
+	msg.AddAlternative("text/html", `<img src="cid:logo">`)
+	msg.Embed("logo", "logo.png", []byte("not really a png"))
+
+	body := msg.Body()
+
+	stdMsg, err := mail.ReadMessage(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(stdMsg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != "multipart/related" {
+		t.Fatalf("got media type %q, want multipart/related", mediaType)
+	}
+
+	mr := multipart.NewReader(stdMsg.Body, params["boundary"])
+
+	altPart, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	altMediaType, altParams, err := mime.ParseMediaType(altPart.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if altMediaType != "multipart/alternative" {
+		t.Fatalf("got media type %q, want multipart/alternative", altMediaType)
+	}
+
+	altReader := multipart.NewReader(altPart, altParams["boundary"])
+	plainPart, err := altReader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := plainPart.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("got first alternative Content-Type %q, want text/plain", ct)
+	}
+	htmlPart, err := altReader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := htmlPart.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("got second alternative Content-Type %q, want text/html", ct)
+	}
+
+	embedPart, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cid := embedPart.Header.Get("Content-ID"); cid != "<logo>" {
+		t.Fatalf("got Content-ID %q, want <logo>", cid)
+	}
+}
@@ -0,0 +1,66 @@
+package goemail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+)
+
+// base64LineLen is the maximum line length for base64-encoded part
+// bodies, per RFC 2045.
+const base64LineLen = 76
+
+// writeHeader writes a single CRLF-terminated header line to buf.
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key + ": " + value + "\r\n")
+}
+
+// encodeWord returns s as an RFC 2047 encoded-word if it contains
+// non-ASCII bytes, and s unchanged otherwise.
+func encodeWord(s string) string {
+	return mime.QEncoding.Encode("utf-8", s)
+}
+
+// encodeDisplayName returns name formatted for use in a From header: a
+// quoted string when name is plain ASCII, matching historical output, or
+// an RFC 2047 encoded-word when it is not, since encoded-words are not
+// themselves quoted.
+func encodeDisplayName(name string) string {
+	if isASCII(name) {
+		return `"` + name + `"`
+	}
+	return encodeWord(name)
+}
+
+// isASCII reports whether s consists entirely of ASCII bytes.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > 127 {
+			return false
+		}
+	}
+	return true
+}
+
+// writeQuotedPrintable writes s to w as quoted-printable encoded text.
+func writeQuotedPrintable(w io.Writer, s string) {
+	qp := quotedprintable.NewWriter(w)
+	_, _ = qp.Write([]byte(s))
+	_ = qp.Close()
+}
+
+// writeBase64 writes data to w as base64, wrapped at base64LineLen
+// columns with CRLF line endings.
+func writeBase64(w io.Writer, data []byte) {
+	enc := base64.StdEncoding.EncodeToString(data)
+	for i := 0; i < len(enc); i += base64LineLen {
+		end := i + base64LineLen
+		if end > len(enc) {
+			end = len(enc)
+		}
+		_, _ = io.WriteString(w, enc[i:end])
+		_, _ = io.WriteString(w, "\r\n")
+	}
+}
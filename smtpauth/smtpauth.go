@@ -0,0 +1,87 @@
+// Package smtpauth implements SMTP AUTH mechanisms not provided by
+// net/smtp: AUTH LOGIN, used by many Microsoft/Office365 and Chinese
+// mail providers, and XOAUTH2, used for OAuth2 bearer-token
+// authentication. AUTH PLAIN and AUTH CRAM-MD5 are already implemented
+// by net/smtp and are not duplicated here.
+package smtpauth
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+)
+
+// ErrWrongHostName is returned by loginAuth when the server name the
+// smtp.Client was created with does not match the host the Auth was configured for, to avoid
+// sending credentials to the wrong server.
+var ErrWrongHostName = errors.New("smtpauth: wrong host name")
+
+// loginAuth implements the AUTH LOGIN mechanism: the server prompts for
+// a username and a password in turn, each carried as a base64 string.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+// LoginAuth returns an smtp.Auth that authenticates using AUTH LOGIN.
+// host must match the server name given to smtp.NewClient; a mismatch
+// aborts the exchange before any credential is sent.
+func LoginAuth(username, password, host string) smtp.Auth {
+	return &loginAuth{username: username, password: password, host: host}
+}
+
+// Start implements smtp.Auth.
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if server.Name != a.host {
+		return "", nil, ErrWrongHostName
+	}
+	return "LOGIN", nil, nil
+}
+
+// Next implements smtp.Auth.
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	switch string(fromServer) {
+	case "Username:":
+		return []byte(a.username), nil
+	case "Password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("smtpauth: unexpected server prompt %q", fromServer)
+	}
+}
+
+// xoauth2Auth implements the XOAUTH2 mechanism, which authenticates with
+// an OAuth2 bearer token in place of a password.
+type xoauth2Auth struct {
+	username string
+	token    string
+}
+
+// XOAuth2Auth returns an smtp.Auth that authenticates using an OAuth2
+// bearer token, as described at
+// https://developers.google.com/gmail/imap/xoauth2-protocol.
+func XOAuth2Auth(username, token string) smtp.Auth {
+	return &xoauth2Auth{username: username, token: token}
+}
+
+// Start implements smtp.Auth.
+func (a *xoauth2Auth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	resp := fmt.Sprintf("user=%s\x01auth=Bearer %s\x01\x01", a.username, a.token)
+	return "XOAUTH2", []byte(resp), nil
+}
+
+// Next implements smtp.Auth. A server that rejects the bearer token
+// sends a JSON error as a challenge; responding with an empty message
+// completes the exchange so the original authentication error surfaces
+// instead of a protocol violation.
+func (a *xoauth2Auth) Next(_ []byte, more bool) ([]byte, error) {
+	if more {
+		return []byte{}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,66 @@
+package smtpauth
+
+import (
+	"net/smtp"
+	"testing"
+)
+
+func TestLoginAuth(t *testing.T) {
+	auth := LoginAuth("user", "pass", "mail.example.com")
+
+	if _, _, err := auth.Start(&smtp.ServerInfo{Name: "wrong.example.com"}); err != ErrWrongHostName {
+		t.Fatalf("got err %v, want ErrWrongHostName", err)
+	}
+
+	proto, toServer, err := auth.Start(&smtp.ServerInfo{Name: "mail.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proto != "LOGIN" {
+		t.Fatalf("got proto %q, want LOGIN", proto)
+	}
+	if toServer != nil {
+		t.Fatalf("got initial response %q, want nil", toServer)
+	}
+
+	resp, err := auth.Next([]byte("Username:"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp) != "user" {
+		t.Fatalf("got %q, want user", resp)
+	}
+
+	resp, err = auth.Next([]byte("Password:"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp) != "pass" {
+		t.Fatalf("got %q, want pass", resp)
+	}
+
+	if resp, err = auth.Next(nil, false); err != nil || resp != nil {
+		t.Fatalf("got (%q, %v), want (nil, nil)", resp, err)
+	}
+}
+
+func TestXOAuth2Auth(t *testing.T) {
+	auth := XOAuth2Auth("user@example.com", "token123")
+
+	proto, toServer, err := auth.Start(&smtp.ServerInfo{Name: "mail.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proto != "XOAUTH2" {
+		t.Fatalf("got proto %q, want XOAUTH2", proto)
+	}
+
+	want := "user=user@example.com\x01auth=Bearer token123\x01\x01"
+	if string(toServer) != want {
+		t.Fatalf("got %q, want %q", toServer, want)
+	}
+
+	if resp, err := auth.Next([]byte(`{"status":"401"}`), true); err != nil || string(resp) != "" {
+		t.Fatalf("got (%q, %v), want empty response and no error", resp, err)
+	}
+}
@@ -0,0 +1,28 @@
+package goemail
+
+import "crypto/tls"
+
+// smtpTLSConfig carries the information dialSMTP needs to build a
+// *tls.Config for both implicit TLS (smtps) and STARTTLS, and whether
+// STARTTLS must succeed when not using implicit TLS.
+type smtpTLSConfig struct {
+	// config, when non-nil, is used as-is and overrides verify and
+	// serverName entirely, for callers that need their own root pool or
+	// a pinned certificate.
+	config *tls.Config
+
+	verify           bool   // verify certs against system roots
+	serverName       string // ServerName used for verification
+	startTLSRequired bool   // for smtp:// only: fail if STARTTLS isn't offered
+}
+
+// tlsConfig returns the *tls.Config to use for the connection.
+func (c smtpTLSConfig) tlsConfig() *tls.Config {
+	if c.config != nil {
+		return c.config
+	}
+	return &tls.Config{
+		ServerName:         c.serverName,
+		InsecureSkipVerify: !c.verify,
+	}
+}
@@ -0,0 +1,62 @@
+package goemail
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestNewSMTP_TLSMode(t *testing.T) {
+	tests := []struct {
+		name             string
+		rawURL           string
+		tlsVerify        bool
+		startTLSRequired bool
+		wantErr          bool
+	}{
+		{"default", "smtp://mail.example.com", true, false, false},
+		{"verify", "smtp://mail.example.com?tls=verify", true, false, false},
+		{"skip", "smtp://mail.example.com?tls=skip", false, false, false},
+		{"starttls-required", "smtp://mail.example.com?tls=starttls-required", true, true, false},
+		{"starttls-optional", "smtp://mail.example.com?tls=starttls-optional", true, false, false},
+		{"invalid", "smtp://mail.example.com?tls=bogus", false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSMTP(tt.rawURL)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.tlsVerify != tt.tlsVerify {
+				t.Fatalf("got tlsVerify %v, want %v", s.tlsVerify, tt.tlsVerify)
+			}
+			if s.startTLSRequired != tt.startTLSRequired {
+				t.Fatalf("got startTLSRequired %v, want %v", s.startTLSRequired, tt.startTLSRequired)
+			}
+			if s.host != "mail.example.com" {
+				t.Fatalf("got host %q, want mail.example.com", s.host)
+			}
+		})
+	}
+}
+
+func TestSMTPTLSConfig_ExplicitOverride(t *testing.T) {
+	s, err := NewSMTP("smtp://mail.example.com?tls=skip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	explicit := &tls.Config{ServerName: "pinned.example.com"}
+	s.TLSConfig = explicit
+
+	got := s.tlsConfig().tlsConfig()
+	if got != explicit {
+		t.Fatal("TLSConfig did not override the derived config")
+	}
+}